@@ -0,0 +1,38 @@
+package thumbnailer
+
+import "bytes"
+
+// detectMP3 checks for an ID3v2 tag or an MPEG audio frame sync, neither of
+// which is guaranteed to sit at offset 0 the way other formats' magic
+// numbers do.
+func detectMP3(buf []byte) (string, bool) {
+	if bytes.HasPrefix(buf, []byte("ID3")) {
+		return "audio/mpeg", true
+	}
+	for i := 0; i < len(buf)-1; i++ {
+		if buf[i] == 0xff && buf[i+1]&0xe0 == 0xe0 {
+			return "audio/mpeg", true
+		}
+	}
+	return "", false
+}
+
+// decodeAudio parses embedded media tags (title, artist, play length) and
+// extracts cover art, if any is embedded. Actual tag and container parsing
+// is delegated to the bundled libavformat bindings in ffmpeg.go.
+func decodeAudio(buf []byte, mime string) (Source, []byte, error) {
+	src := Source{Mime: mime, Extension: extensions[mime]}
+
+	tags, err := readMediaTags(buf)
+	if err != nil {
+		return src, nil, err
+	}
+	src.Title = tags.title
+	src.Artist = tags.artist
+	src.Length = tags.length
+
+	if len(tags.cover) == 0 {
+		return src, nil, ErrNoCoverArt
+	}
+	return src, tags.cover, nil
+}
@@ -0,0 +1,86 @@
+// Package cache provides an on-disk thumbnail cache, keyed by source
+// content hash and rendition spec, so repeated thumbnailing of the same
+// source doesn't repeat the ffmpeg/decode cost on re-serve.
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Key identifies a single cached rendition: a source, by content hash, and
+// the spec it was rendered with.
+type Key struct {
+	// Hash is the content hash of the source file, typically hex-encoded
+	// SHA-256
+	Hash string
+
+	// Width and Height are the rendition's requested dimensions, which need
+	// not equal the encoded output's size
+	Width, Height uint
+
+	// Mode identifies the fitting mode the rendition was generated with,
+	// e.g. "thumbnail", "fit" or "resize"
+	Mode string
+
+	// Ext is the file extension of the encoded rendition, e.g. "jpg" or
+	// "png"
+	Ext string
+}
+
+// filename returns the key's canonical on-disk file name
+func (k Key) filename() string {
+	return fmt.Sprintf("%s_%dx%d_%s.%s", k.Hash, k.Width, k.Height, k.Mode, k.Ext)
+}
+
+// Cache stores and retrieves encoded thumbnail renditions
+type Cache interface {
+	// Get returns the cached data for key, if present
+	Get(key Key) ([]byte, bool)
+
+	// Put stores data under key, overwriting any existing entry
+	Put(key Key, data []byte) error
+}
+
+// FSCache is a Cache backed by a directory tree. Entries are stored under
+// <root>/<hash[:2]>/<hash>_<width>x<height>_<mode>.<ext>, sharding by the
+// first two hash characters to keep any single directory from growing
+// unbounded.
+type FSCache struct {
+	root string
+}
+
+// NewFSCache returns an FSCache rooted at root. root is created on first
+// Put, if it does not already exist.
+func NewFSCache(root string) *FSCache {
+	return &FSCache{root: root}
+}
+
+// path returns the on-disk path for key
+func (c *FSCache) path(key Key) string {
+	shard := key.Hash
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(c.root, shard, key.filename())
+}
+
+// Get implements Cache
+func (c *FSCache) Get(key Key) ([]byte, bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put implements Cache
+func (c *FSCache) Put(key Key, data []byte) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
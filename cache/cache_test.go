@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSCache(t *testing.T) {
+	t.Parallel()
+
+	root, err := ioutil.TempDir("", "thumbnailer-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	c := NewFSCache(root)
+	key := Key{Hash: "abcdef0123456789", Width: 150, Height: 150, Mode: "thumbnail", Ext: "jpg"}
+
+	if _, ok := c.Get(key); ok {
+		t.Fatal("expected cache miss before any Put")
+	}
+
+	data := []byte("fake jpeg data")
+	if err := c.Put(key, data); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := c.Get(key)
+	if !ok {
+		t.Fatal("expected cache hit after Put")
+	}
+	if string(got) != string(data) {
+		t.Errorf("unexpected data: %q : %q", data, got)
+	}
+
+	want := filepath.Join(root, "ab", "abcdef0123456789_150x150_thumbnail.jpg")
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("expected file at %s: %s", want, err)
+	}
+}
+
+func TestFSCacheMiss(t *testing.T) {
+	t.Parallel()
+
+	c := NewFSCache(filepath.Join(os.TempDir(), "thumbnailer-cache-nonexistent"))
+	if _, ok := c.Get(Key{Hash: "nope"}); ok {
+		t.Fatal("expected cache miss for nonexistent root")
+	}
+}
@@ -0,0 +1,60 @@
+package thumbnailer
+
+// Dims store the dimensions of a source or thumbnail image or video
+type Dims struct {
+	Width, Height uint
+}
+
+// Mode determines how a source image is fit into the bounding box described
+// by a rendition's Dims.
+type Mode int
+
+// Mode constants are prefixed with Mode to avoid colliding with the
+// Thumbnail result type.
+const (
+	// ModeThumbnail crops the source to fill the bounding box exactly,
+	// discarding any overflow. A source that already fits inside the box on
+	// both axes is kept at its own size instead. This is the historical, and
+	// still default, behaviour of this package.
+	ModeThumbnail Mode = iota
+
+	// ModeFit letterboxes the source inside the bounding box, preserving the
+	// full image and its aspect ratio. The resulting thumbnail dimensions
+	// may be smaller than the requested box on one axis.
+	ModeFit
+
+	// ModeResize scales the source to the exact bounding box, ignoring
+	// aspect ratio. A dimension of 0 means "compute automatically to
+	// preserve aspect ratio", mirroring the convention used by
+	// disintegration/imaging.
+	ModeResize
+)
+
+// String returns the lowercase name of m, as used in cache.Key.Mode
+func (m Mode) String() string {
+	switch m {
+	case ModeFit:
+		return "fit"
+	case ModeResize:
+		return "resize"
+	default:
+		return "thumbnail"
+	}
+}
+
+// Rendition describes a single named output thumbnail to produce from a
+// source. Several renditions can be requested from a single call to
+// ProcessRenditions, so that decoding of the source only happens once.
+type Rendition struct {
+	// Name identifies this rendition in the returned map. Must be unique
+	// within a single Options.Renditions slice.
+	Name string
+
+	// Dims is the bounding box the rendition is fit into. Interpretation
+	// depends on Mode.
+	Dims Dims
+
+	// Mode determines how the source is fit into Dims. Defaults to
+	// ModeThumbnail (crop-to-fit).
+	Mode Mode
+}
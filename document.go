@@ -0,0 +1,40 @@
+package thumbnailer
+
+// #include "src/document.h"
+import "C"
+
+import (
+	"errors"
+	"image"
+	"unsafe"
+)
+
+// decodeDocument rasterizes the first page of a PDF, or the flattened
+// composite of a PSD, to an RGBA image via Poppler/GraphicsMagick.
+//
+// PDF page dimensions are a property of the document's page geometry, not
+// the rendering device, so they are exempt from Options.MaxSourceDims -
+// see the skip in processSource.
+func decodeDocument(buf []byte, mime string) (image.Image, error) {
+	isPSD := C.int(0)
+	if mime == "image/vnd.adobe.photoshop" {
+		isPSD = 1
+	}
+
+	var (
+		rgba          *C.uchar
+		width, height C.uint
+	)
+	ret := C.render_document(
+		unsafe.Pointer(&buf[0]), C.size_t(len(buf)), isPSD,
+		&rgba, &width, &height,
+	)
+	if ret != 0 {
+		return nil, errors.New(`could not render document`)
+	}
+	defer C.free_rendered_document(rgba)
+
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	copy(img.Pix, C.GoBytes(unsafe.Pointer(rgba), C.int(width*height*4)))
+	return img, nil
+}
@@ -0,0 +1,30 @@
+package thumbnailer
+
+import "errors"
+
+// Various errors returned by Process and its helpers.
+var (
+	// ErrNoCoverArt denotes that a source had no usable embedded cover art or
+	// video frame to generate a thumbnail from. Source metadata is still
+	// extracted and returned alongside this error.
+	ErrNoCoverArt = errors.New(`no cover art found`)
+
+	// ErrTooWide is returned, when a source's width exceeds
+	// Options.MaxSourceDims.Width
+	ErrTooWide = errors.New(`source too wide`)
+
+	// ErrTooTall is returned, when a source's height exceeds
+	// Options.MaxSourceDims.Height
+	ErrTooTall = errors.New(`source too tall`)
+
+	// ErrTooLarge is returned, when a source's total pixel count exceeds
+	// Options.MaxSourcePixels. Unlike ErrTooWide/ErrTooTall, this also
+	// catches sources whose individual dimensions are within bounds, but
+	// whose product is large enough to be a decompression-bomb risk, e.g. a
+	// wide, short panorama.
+	ErrTooLarge = errors.New(`source has too many pixels`)
+
+	// ErrUnsupportedMIME denotes that the detected source MIME type is not
+	// supported by this package
+	ErrUnsupportedMIME = errors.New(`unsupported MIME type`)
+)
@@ -0,0 +1,123 @@
+package thumbnailer
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+)
+
+// EXIF orientation tag values, as defined by the TIFF/EXIF specification
+const (
+	orientationNormal = 1
+	orientationFlipH  = 2
+	orientation180    = 3
+	orientationFlipV  = 4
+	orientationTransp = 5
+	orientation90CW   = 6
+	orientationTransv = 7
+	orientation90CCW  = 8
+)
+
+// readEXIFOrientation scans a JPEG's APP1 segment for the EXIF orientation
+// tag (0x0112) and returns its value, or orientationNormal if absent or
+// unparseable. Errors are deliberately swallowed: a missing or malformed
+// EXIF block is not fatal to thumbnailing, it just forgoes rotation.
+func readEXIFOrientation(buf []byte) int {
+	app1 := bytes.Index(buf, []byte("Exif\x00\x00"))
+	if app1 == -1 {
+		return orientationNormal
+	}
+	tiff := buf[app1+6:]
+
+	var bo binaryOrder
+	switch {
+	case bytes.HasPrefix(tiff, []byte("II")):
+		bo = littleEndian
+	case bytes.HasPrefix(tiff, []byte("MM")):
+		bo = bigEndian
+	default:
+		return orientationNormal
+	}
+
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+	ifdOffset := bo.uint32(tiff[4:8])
+	if int(ifdOffset)+2 > len(tiff) {
+		return orientationNormal
+	}
+
+	entries := bo.uint16(tiff[ifdOffset : ifdOffset+2])
+	pos := int(ifdOffset) + 2
+	for i := uint16(0); i < entries; i++ {
+		if pos+12 > len(tiff) {
+			break
+		}
+		entry := tiff[pos : pos+12]
+		if bo.uint16(entry[0:2]) == 0x0112 {
+			return int(bo.uint16(entry[8:10]))
+		}
+		pos += 12
+	}
+	return orientationNormal
+}
+
+type binaryOrder int
+
+const (
+	littleEndian binaryOrder = iota
+	bigEndian
+)
+
+func (o binaryOrder) uint16(b []byte) uint16 {
+	if o == littleEndian {
+		return uint16(b[0]) | uint16(b[1])<<8
+	}
+	return uint16(b[1]) | uint16(b[0])<<8
+}
+
+func (o binaryOrder) uint32(b []byte) uint32 {
+	if o == littleEndian {
+		return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	}
+	return uint32(b[3]) | uint32(b[2])<<8 | uint32(b[1])<<16 | uint32(b[0])<<24
+}
+
+// applyEXIFOrientation rotates/flips img so that it displays upright,
+// undoing the transform described by the EXIF orientation tag o.
+func applyEXIFOrientation(img image.Image, o int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	rotate := func(src image.Image, w, h int, at func(x, y int) (int, int)) *image.RGBA {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				sx, sy := at(x, y)
+				dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+			}
+		}
+		return dst
+	}
+
+	switch o {
+	case orientationFlipH:
+		return rotate(img, w, h, func(x, y int) (int, int) { return w - 1 - x, y })
+	case orientation180:
+		return rotate(img, w, h, func(x, y int) (int, int) { return w - 1 - x, h - 1 - y })
+	case orientationFlipV:
+		return rotate(img, w, h, func(x, y int) (int, int) { return x, h - 1 - y })
+	case orientation90CW:
+		return rotate(img, h, w, func(x, y int) (int, int) { return y, h - 1 - x })
+	case orientationTransp:
+		return rotate(img, h, w, func(x, y int) (int, int) { return y, x })
+	case orientation90CCW:
+		return rotate(img, h, w, func(x, y int) (int, int) { return w - 1 - y, x })
+	case orientationTransv:
+		return rotate(img, h, w, func(x, y int) (int, int) { return w - 1 - y, h - 1 - x })
+	default:
+		dst := image.NewRGBA(b)
+		draw.Draw(dst, b, img, b.Min, draw.Src)
+		return dst
+	}
+}
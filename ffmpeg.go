@@ -0,0 +1,111 @@
+package thumbnailer
+
+// #cgo pkg-config: libavcodec libavformat libavutil libswscale
+// #include "src/ffmpeg.h"
+import "C"
+
+import (
+	"image"
+	"time"
+	"unsafe"
+)
+
+// mediaTags holds metadata parsed from a video or audio container via
+// libavformat
+type mediaTags struct {
+	title, artist string
+	length        float64
+	cover         []byte
+}
+
+// readMediaTags opens buf as an in-memory AVFormatContext and reads its
+// metadata dictionary and, if present, the attached picture stream used for
+// embedded cover art.
+func readMediaTags(buf []byte) (mediaTags, error) {
+	var t C.struct_mediaTags
+	ret := C.read_media_tags(
+		unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &t,
+	)
+	defer C.free_media_tags(&t)
+	if ret != 0 {
+		return mediaTags{}, ffmpegError(ret)
+	}
+
+	out := mediaTags{
+		title:  C.GoString(t.title),
+		artist: C.GoString(t.artist),
+		length: float64(t.length),
+	}
+	if t.cover_size > 0 {
+		out.cover = C.GoBytes(unsafe.Pointer(t.cover), C.int(t.cover_size))
+	}
+	return out, nil
+}
+
+// probeVideoDims reads the best video stream's dimensions from its codec
+// parameters, without decoding any frames - cheap enough to validate
+// against Options.MaxSourceDims/MaxSourcePixels before committing to the
+// full decodeVideoFrame call.
+func probeVideoDims(buf []byte) (Dims, error) {
+	var w, h C.uint
+	ret := C.probe_video_dims(
+		unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &w, &h,
+	)
+	if ret != 0 {
+		return Dims{}, ffmpegError(ret)
+	}
+	return Dims{Width: uint(w), Height: uint(h)}, nil
+}
+
+// decodeVideoFrame seeks to a representative frame of the video stream in
+// buf - skipping any leading black frames, as detected by a brightness
+// histogram scan - decodes it and converts it from the stream's native
+// pixel format to RGBA via libswscale.
+func decodeVideoFrame(buf []byte) (image.Image, error) {
+	var f C.struct_decodedFrame
+	ret := C.decode_video_frame(
+		unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &f,
+	)
+	defer C.free_decoded_frame(&f)
+	if ret != 0 {
+		return nil, ffmpegError(ret)
+	}
+
+	w, h := int(f.width), int(f.height)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	src := C.GoBytes(unsafe.Pointer(f.rgba), C.int(w*h*4))
+	copy(img.Pix, src)
+	return img, nil
+}
+
+// decodeVideoFrameAt seeks to timestamp in the video stream in buf, decodes
+// the next frame and converts it to RGBA. Used to sample evenly-spaced
+// frames when building a motion preview strip.
+func decodeVideoFrameAt(buf []byte, timestamp time.Duration) (image.Image, error) {
+	var f C.struct_decodedFrame
+	ret := C.decode_video_frame_at(
+		unsafe.Pointer(&buf[0]), C.size_t(len(buf)),
+		C.double(timestamp.Seconds()), &f,
+	)
+	defer C.free_decoded_frame(&f)
+	if ret != 0 {
+		return nil, ffmpegError(ret)
+	}
+
+	w, h := int(f.width), int(f.height)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	src := C.GoBytes(unsafe.Pointer(f.rgba), C.int(w*h*4))
+	copy(img.Pix, src)
+	return img, nil
+}
+
+// ffmpegError converts a libavformat/libavcodec error code to a Go error
+func ffmpegError(code C.int) error {
+	buf := make([]byte, 128)
+	C.av_strerror(code, (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))
+	return errorString(C.GoString((*C.char)(unsafe.Pointer(&buf[0]))))
+}
+
+type errorString string
+
+func (e errorString) Error() string { return string(e) }
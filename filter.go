@@ -0,0 +1,27 @@
+package thumbnailer
+
+// Filter selects the resampling kernel used when downsampling a source
+// image or video frame to a thumbnail's target dimensions.
+type Filter int
+
+const (
+	// FilterBilinear uses a triangle filter. This was the only filter
+	// available before Filter was introduced and remains the default.
+	FilterBilinear Filter = iota
+
+	// FilterBicubic uses a sharpening cubic convolution filter
+	FilterBicubic
+
+	// FilterCatmullRom uses a softer cubic convolution filter, a common
+	// general-purpose choice for photographic downsampling
+	FilterCatmullRom
+
+	// FilterLanczos3 uses a 3-lobe windowed sinc filter, giving the
+	// sharpest results at the cost of some ringing around hard edges
+	FilterLanczos3
+
+	// FilterNearest performs no interpolation, sampling the nearest source
+	// pixel. Appropriate for pixel art and small sprites, where
+	// interpolation would blur hard pixel boundaries.
+	FilterNearest
+)
@@ -0,0 +1,165 @@
+package thumbnailer
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// filterSamples are representative of the distinct content types a filter
+// choice visibly affects: a photographic scan, a screenshot-style PNG with
+// hard edges, and a palette-based GIF.
+var filterSamples = []string{
+	"sample.tiff",
+	"sample.png",
+	"sample.gif",
+}
+
+var allFilters = []Filter{
+	FilterBilinear,
+	FilterBicubic,
+	FilterCatmullRom,
+	FilterLanczos3,
+	FilterNearest,
+}
+
+// filterNames labels allFilters' members for use as subtest names, since
+// Filter has no String method of its own.
+var filterNames = map[Filter]string{
+	FilterBilinear:   "bilinear",
+	FilterBicubic:    "bicubic",
+	FilterCatmullRom: "catmull_rom",
+	FilterLanczos3:   "lanczos3",
+	FilterNearest:    "nearest",
+}
+
+func TestFilters(t *testing.T) {
+	t.Parallel()
+
+	for _, sample := range filterSamples {
+		sample := sample
+		t.Run(sample, func(t *testing.T) {
+			t.Parallel()
+
+			for _, filter := range allFilters {
+				f := openSample(t, sample)
+				_, thumb, err := Process(f, Options{
+					JPEGQuality: 90,
+					ThumbDims:   Dims{150, 150},
+					Filter:      filter,
+				})
+				f.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if thumb.Width != 150 || thumb.Height != 150 {
+					t.Errorf("%v: unexpected dims: %v", filter, thumb.Dims)
+				}
+			}
+		})
+	}
+}
+
+// decodePNGOrJPEG decodes whichever of the two formats buf contains, for use
+// in comparing two generated thumbnails directly rather than against a
+// separately maintained golden file.
+func decodePNGOrJPEG(t *testing.T, buf []byte) image.Image {
+	t.Helper()
+	mime, err := detectMIME(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := decodeImage(buf, mime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// psnr computes the peak signal-to-noise ratio between two equally-sized
+// images, in decibels. Higher is more similar; identical images yield +Inf.
+func psnr(a, b image.Image) float64 {
+	bounds := a.Bounds()
+	var sum, n float64
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ar, ag, ab, _ := a.At(x, y).RGBA()
+			br, bg, bb, _ := b.At(x, y).RGBA()
+			for _, d := range []float64{
+				float64(ar>>8) - float64(br>>8),
+				float64(ag>>8) - float64(bg>>8),
+				float64(ab>>8) - float64(bb>>8),
+			} {
+				sum += d * d
+				n++
+			}
+		}
+	}
+
+	mse := sum / n
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 20*math.Log10(255) - 10*math.Log10(mse)
+}
+
+// TestFilterQuality compares every Filter's output against a FilterBilinear
+// reference thumbnail of the same source by PSNR, across all of
+// filterSamples, asserting that the Filter option actually reaches the C
+// resampler instead of being silently ignored: a filter that behaves
+// identically to the reference would report +Inf here.
+func TestFilterQuality(t *testing.T) {
+	t.Parallel()
+
+	for _, sample := range filterSamples {
+		sample := sample
+		t.Run(sample, func(t *testing.T) {
+			t.Parallel()
+
+			f := openSample(t, sample)
+			defer f.Close()
+			_, reference, err := Process(f, Options{
+				JPEGQuality: 90,
+				ThumbDims:   Dims{150, 150},
+				Filter:      FilterBilinear,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			referenceImg := decodePNGOrJPEG(t, reference.Data)
+
+			for _, filter := range allFilters {
+				filter := filter
+				t.Run(filterNames[filter], func(t *testing.T) {
+					t.Parallel()
+
+					f := openSample(t, sample)
+					defer f.Close()
+					_, thumb, err := Process(f, Options{
+						JPEGQuality: 90,
+						ThumbDims:   Dims{150, 150},
+						Filter:      filter,
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					ratio := psnr(referenceImg, decodePNGOrJPEG(t, thumb.Data))
+					t.Logf("PSNR vs FilterBilinear reference: %.2fdB", ratio)
+
+					switch filter {
+					case FilterBilinear:
+						if !math.IsInf(ratio, 1) {
+							t.Errorf("FilterBilinear should match its own reference exactly: got %.2fdB", ratio)
+						}
+					case FilterNearest:
+						if math.IsInf(ratio, 1) {
+							t.Error("FilterNearest produced byte-identical output to FilterBilinear")
+						}
+					}
+				})
+			}
+		})
+	}
+}
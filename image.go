@@ -0,0 +1,70 @@
+package thumbnailer
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
+)
+
+// hasAlpha reports whether img contains any pixel with partial or full
+// transparency, which decides whether a thumbnail is encoded as PNG instead
+// of JPEG.
+func hasAlpha(img image.Image) bool {
+	switch img.(type) {
+	case *image.Gray, *image.Gray16, *image.CMYK, *image.YCbCr:
+		return false
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeImage decodes a still-image source by its detected MIME type and,
+// for JPEG sources only, corrects its orientation according to any embedded
+// EXIF orientation tag.
+func decodeImage(buf []byte, mime string) (image.Image, error) {
+	var (
+		img image.Image
+		err error
+	)
+
+	r := bytes.NewReader(buf)
+	switch mime {
+	case "image/jpeg":
+		img, err = jpeg.Decode(r)
+	case "image/png":
+		img, err = png.Decode(r)
+	case "image/gif":
+		img, err = gif.Decode(r)
+	case "image/bmp":
+		img, err = bmp.Decode(r)
+	case "image/tiff":
+		img, err = tiff.Decode(r)
+	case "image/webp":
+		img, err = webp.Decode(r)
+	default:
+		return nil, ErrUnsupportedMIME
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if mime == "image/jpeg" {
+		if o := readEXIFOrientation(buf); o != orientationNormal {
+			img = applyEXIFOrientation(img, o)
+		}
+	}
+	return img, nil
+}
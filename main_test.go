@@ -1,6 +1,7 @@
 package thumbnailer
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -138,6 +139,7 @@ func TestDimensionValidation(t *testing.T) {
 	cases := [...]struct {
 		name, file string
 		maxW, maxH uint
+		maxPixels  uint64
 		err        error
 	}{
 		{
@@ -166,6 +168,16 @@ func TestDimensionValidation(t *testing.T) {
 			maxH: 1,
 			maxW: 1,
 		},
+		{
+			name: "total pixels check disabled",
+			file: "too wide.jpg",
+		},
+		{
+			name:      "too many total pixels",
+			file:      "too wide.jpg",
+			maxPixels: 1000,
+			err:       ErrTooLarge,
+		},
 	}
 
 	for i := range cases {
@@ -182,7 +194,8 @@ func TestDimensionValidation(t *testing.T) {
 					Width:  c.maxW,
 					Height: c.maxH,
 				},
-				JPEGQuality: 90,
+				MaxSourcePixels: c.maxPixels,
+				JPEGQuality:     90,
 			}
 
 			f := openSample(t, c.file)
@@ -257,6 +270,104 @@ func TestWebmAlpha(t *testing.T) {
 	}
 }
 
+func TestProcessCanceledContext(t *testing.T) {
+	t.Parallel()
+
+	f := openSample(t, "sample.jpg")
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := Process(f, Options{
+		Ctx:       ctx,
+		ThumbDims: Dims{150, 150},
+	})
+	if err != context.Canceled {
+		t.Fatalf("unexpected error: `%s` : `%s`", context.Canceled, err)
+	}
+}
+
+func TestProcessProgressCallback(t *testing.T) {
+	t.Parallel()
+
+	f := openSample(t, "sample.jpg")
+	defer f.Close()
+
+	var stages []string
+	_, _, err := Process(f, Options{
+		ThumbDims: Dims{150, 150},
+		ProgressCallback: func(stage string, done, total int64) {
+			stages = append(stages, stage)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"probe", "encode"} {
+		var found bool
+		for _, s := range stages {
+			if s == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected stage %q to be reported: got %v", want, stages)
+		}
+	}
+}
+
+func TestProcessRenditions(t *testing.T) {
+	t.Parallel()
+
+	f := openSample(t, "sample.jpg")
+	defer f.Close()
+
+	src, thumbs, err := ProcessRenditions(f, Options{
+		JPEGQuality: 90,
+		Renditions: []Rendition{
+			{Name: "thumb", Dims: Dims{150, 150}, Mode: ModeThumbnail},
+			{Name: "fit", Dims: Dims{150, 150}, Mode: ModeFit},
+			{Name: "resize", Dims: Dims{150, 0}, Mode: ModeResize},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Width == 0 || src.Height == 0 {
+		t.Error("expected source dims to be populated")
+	}
+	if len(thumbs) != 3 {
+		t.Fatalf("expected 3 renditions: got %d", len(thumbs))
+	}
+
+	thumb, ok := thumbs["thumb"]
+	if !ok {
+		t.Fatal("missing thumb rendition")
+	}
+	if thumb.Width != 150 || thumb.Height != 150 {
+		t.Errorf("unexpected thumb dims: %v", thumb.Dims)
+	}
+
+	fit, ok := thumbs["fit"]
+	if !ok {
+		t.Fatal("missing fit rendition")
+	}
+	if fit.Width > 150 || fit.Height > 150 {
+		t.Errorf("fit rendition exceeds bounding box: %v", fit.Dims)
+	}
+
+	resize, ok := thumbs["resize"]
+	if !ok {
+		t.Fatal("missing resize rendition")
+	}
+	if resize.Width != 150 {
+		t.Errorf("unexpected resize width: 150 : %d", resize.Width)
+	}
+}
+
 // Called on `go test -args all`
 func TestPanic(t *testing.T) {
 	if len(os.Args) != 2 || os.Args[1] != "all" {
@@ -0,0 +1,112 @@
+package thumbnailer
+
+import "bytes"
+
+// extensions maps a detected MIME type to its canonical file extension
+var extensions = map[string]string{
+	"image/jpeg":                "jpg",
+	"image/png":                 "png",
+	"image/gif":                 "gif",
+	"image/bmp":                 "bmp",
+	"image/tiff":                "tiff",
+	"image/webp":                "webp",
+	"image/vnd.adobe.photoshop": "psd",
+	"application/pdf":           "pdf",
+	"video/mp4":                 "mp4",
+	"video/webm":                "webm",
+	"video/x-matroska":          "mkv",
+	"video/quicktime":           "mov",
+	"video/x-msvideo":           "avi",
+	"video/x-flv":               "flv",
+	"video/x-ms-wmv":            "wmv",
+	"audio/mpeg":                "mp3",
+	"audio/ogg":                 "ogg",
+	"audio/flac":                "flac",
+}
+
+// magic is a single file signature used for MIME sniffing
+type magic struct {
+	mime   string
+	offset int
+	sig    []byte
+}
+
+// signatures are checked in order; the first match wins. Formats, like MP3
+// and the WebM/Matroska pair, that have no single reliably-distinguishing
+// leading magic number are detected separately, by detectMP3/detectEBML,
+// and are not listed here.
+var signatures = []magic{
+	{"image/jpeg", 0, []byte{0xff, 0xd8, 0xff}},
+	{"image/png", 0, []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}},
+	{"image/gif", 0, []byte("GIF8")},
+	{"image/bmp", 0, []byte("BM")},
+	{"image/tiff", 0, []byte{0x49, 0x49, 0x2a, 0x00}},
+	{"image/tiff", 0, []byte{0x4d, 0x4d, 0x00, 0x2a}},
+	{"image/webp", 8, []byte("WEBP")},
+	{"image/vnd.adobe.photoshop", 0, []byte("8BPS")},
+	{"application/pdf", 0, []byte("%PDF")},
+	{"video/quicktime", 4, []byte("ftypqt")},
+	{"video/mp4", 4, []byte("ftyp")},
+	{"audio/flac", 0, []byte("fLaC")},
+	{"audio/ogg", 0, []byte("OggS")},
+	{"video/x-msvideo", 0, []byte("RIFF")},
+	{"video/x-flv", 0, []byte("FLV")},
+	{"video/x-ms-wmv", 0, []byte{0x30, 0x26, 0xb2, 0x75}},
+}
+
+// ebmlHeader is the leading magic number shared by both EBML-based
+// container formats this package handles, WebM and Matroska. Telling them
+// apart requires looking past the header, at the DocType element - see
+// detectEBML.
+var ebmlHeader = []byte{0x1a, 0x45, 0xdf, 0xa3}
+
+// ebmlScanWindow bounds how far into buf detectEBML looks for the DocType
+// element. The EBML header and DocType are always among the first handful
+// of elements in a well-formed stream, so this comfortably covers real
+// files without scanning the whole buffer.
+const ebmlScanWindow = 4096
+
+// detectEBML disambiguates WebM from Matroska for a buf that already
+// starts with ebmlHeader. Both formats declare their container kind in an
+// ASCII DocType element ("webm" or "matroska") near the start of the
+// stream, so a substring search stands in for a full EBML element parse.
+func detectEBML(buf []byte) (string, bool) {
+	end := len(buf)
+	if end > ebmlScanWindow {
+		end = ebmlScanWindow
+	}
+	head := buf[:end]
+	switch {
+	case bytes.Contains(head, []byte("matroska")):
+		return "video/x-matroska", true
+	case bytes.Contains(head, []byte("webm")):
+		return "video/webm", true
+	}
+	return "", false
+}
+
+// detectMIME sniffs the MIME type of buf, which must contain at least the
+// first 32 bytes of the source. MP3 detection falls back to detectMP3, as
+// MP3 lacks a reliable leading magic number.
+func detectMIME(buf []byte) (string, error) {
+	if bytes.HasPrefix(buf, ebmlHeader) {
+		if mime, ok := detectEBML(buf); ok {
+			return mime, nil
+		}
+		return "video/webm", nil
+	}
+
+	for _, m := range signatures {
+		end := m.offset + len(m.sig)
+		if end > len(buf) {
+			continue
+		}
+		if bytes.Equal(buf[m.offset:end], m.sig) {
+			return m.mime, nil
+		}
+	}
+	if mime, ok := detectMP3(buf); ok {
+		return mime, nil
+	}
+	return "", ErrUnsupportedMIME
+}
@@ -0,0 +1,206 @@
+package thumbnailer
+
+// #cgo pkg-config: libwebp libwebpmux
+// #include <stdlib.h>
+// #include "src/webp.h"
+import "C"
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"time"
+	"unsafe"
+)
+
+// MotionFormat selects the container a motion preview strip is encoded as
+type MotionFormat int
+
+const (
+	// MotionAnimatedWebP encodes the sampled frames as an animated WebP
+	MotionAnimatedWebP MotionFormat = iota
+
+	// MotionAnimatedGIF encodes the sampled frames as an animated GIF
+	MotionAnimatedGIF
+
+	// MotionSprite composites the sampled frames into a single contact
+	// sheet image, arranged left-to-right in one row
+	MotionSprite
+)
+
+// MotionThumbOptions configures the generation of a motion preview strip
+// from a video source - a hover/scrub preview that a single cover frame
+// can't provide.
+type MotionThumbOptions struct {
+	// Frames is the number of frames to sample from the source
+	Frames int
+
+	// Interval is the spacing between sampled timestamps, starting at 0
+	Interval time.Duration
+
+	// Format selects the output container
+	Format MotionFormat
+
+	// Dims bounds each sampled frame, using the ModeThumbnail (crop-to-fit)
+	// mode
+	Dims Dims
+}
+
+// MotionThumbnail is a generated motion preview strip
+type MotionThumbnail struct {
+	Thumbnail
+	Format MotionFormat
+}
+
+// ErrNotVideo is returned by ProcessMotionThumb, when the source is not a
+// video format
+var ErrNotVideo = errors.New(`source is not a video`)
+
+// ErrInvalidMotionThumb is returned by ProcessMotionThumb, when
+// Options.MotionThumb specifies a non-positive Frames count or a
+// zero-dimension Dims, neither of which can produce any frames
+var ErrInvalidMotionThumb = errors.New(`invalid motion thumbnail options`)
+
+// ProcessMotionThumb reads a video source and produces a MotionThumbnail by
+// seeking to Options.MotionThumb.Frames evenly-spaced timestamps, spaced
+// Options.MotionThumb.Interval apart, and downsampling each through the
+// existing thumbnail path before compositing or muxing them per
+// Options.MotionThumb.Format.
+func ProcessMotionThumb(rs io.ReadSeeker, opts Options) (MotionThumbnail, error) {
+	if opts.MotionThumb == nil {
+		return MotionThumbnail{}, errors.New(`Options.MotionThumb not set`)
+	}
+	mt := *opts.MotionThumb
+	if mt.Frames <= 0 || mt.Dims.Width == 0 || mt.Dims.Height == 0 {
+		return MotionThumbnail{}, ErrInvalidMotionThumb
+	}
+
+	buf, err := ioutil.ReadAll(rs)
+	if err != nil {
+		return MotionThumbnail{}, err
+	}
+	mime, err := detectMIME(buf)
+	if err != nil {
+		return MotionThumbnail{}, err
+	}
+	if !videoMimes[mime] {
+		return MotionThumbnail{}, ErrNotVideo
+	}
+
+	frames := make([]*image.RGBA, 0, mt.Frames)
+	for i := 0; i < mt.Frames; i++ {
+		if err := opts.checkCtx(); err != nil {
+			return MotionThumbnail{}, err
+		}
+		opts.report("motion_frame", int64(i), int64(mt.Frames))
+
+		img, err := decodeVideoFrameAt(buf, time.Duration(i)*mt.Interval)
+		if err != nil {
+			return MotionThumbnail{}, err
+		}
+		rgba := toRGBA(img)
+		frames = append(frames, resizeRGBA(cropToAspect(rgba, mt.Dims), mt.Dims.Width, mt.Dims.Height, opts.Filter))
+	}
+	opts.report("motion_frame", int64(mt.Frames), int64(mt.Frames))
+
+	switch mt.Format {
+	case MotionSprite:
+		return encodeSprite(frames, mt, opts.JPEGQuality)
+	case MotionAnimatedGIF:
+		return encodeMotionGIF(frames, mt)
+	default:
+		return encodeMotionWebP(frames, mt)
+	}
+}
+
+// encodeSprite composites frames left-to-right into a single contact sheet
+func encodeSprite(frames []*image.RGBA, mt MotionThumbOptions, jpegQuality int) (MotionThumbnail, error) {
+	w, h := int(mt.Dims.Width), int(mt.Dims.Height)
+	sheet := image.NewRGBA(image.Rect(0, 0, w*len(frames), h))
+	for i, f := range frames {
+		draw.Draw(sheet, image.Rect(i*w, 0, (i+1)*w, h), f, image.Point{}, draw.Src)
+	}
+
+	buf := new(bytes.Buffer)
+	isPNG := hasAlpha(sheet)
+	var err error
+	if isPNG {
+		err = png.Encode(buf, sheet)
+	} else {
+		err = jpeg.Encode(buf, sheet, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return MotionThumbnail{}, err
+	}
+
+	return MotionThumbnail{
+		Thumbnail: Thumbnail{
+			IsPNG: isPNG,
+			Dims:  Dims{Width: uint(sheet.Rect.Dx()), Height: uint(sheet.Rect.Dy())},
+			Data:  buf.Bytes(),
+		},
+		Format: MotionSprite,
+	}, nil
+}
+
+// encodeMotionGIF encodes frames as an animated GIF via the stdlib encoder
+func encodeMotionGIF(frames []*image.RGBA, mt MotionThumbOptions) (MotionThumbnail, error) {
+	g := &gif.GIF{}
+	delay := int(mt.Interval / (10 * time.Millisecond)) // GIF delays are in 1/100ths of a second
+
+	for _, f := range frames {
+		pal := image.NewPaletted(f.Rect, palette.Plan9)
+		draw.Draw(pal, f.Rect, f, image.Point{}, draw.Src)
+		g.Image = append(g.Image, pal)
+		g.Delay = append(g.Delay, delay)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := gif.EncodeAll(buf, g); err != nil {
+		return MotionThumbnail{}, err
+	}
+
+	return MotionThumbnail{
+		Thumbnail: Thumbnail{
+			Dims: mt.Dims,
+			Data: buf.Bytes(),
+		},
+		Format: MotionAnimatedGIF,
+	}, nil
+}
+
+// encodeMotionWebP muxes frames into an animated WebP via libwebpmux
+func encodeMotionWebP(frames []*image.RGBA, mt MotionThumbOptions) (MotionThumbnail, error) {
+	ptrs := make([]*C.uchar, len(frames))
+	for i, f := range frames {
+		ptrs[i] = (*C.uchar)(unsafe.Pointer(&f.Pix[0]))
+	}
+
+	var out *C.uchar
+	var outSize C.size_t
+	ret := C.encode_animated_webp(
+		&ptrs[0], C.int(len(frames)),
+		C.uint(mt.Dims.Width), C.uint(mt.Dims.Height),
+		C.int(mt.Interval/time.Millisecond),
+		&out, &outSize,
+	)
+	if ret != 0 {
+		return MotionThumbnail{}, errors.New(`could not encode animated webp`)
+	}
+	defer C.free(unsafe.Pointer(out))
+
+	return MotionThumbnail{
+		Thumbnail: Thumbnail{
+			Dims: mt.Dims,
+			Data: C.GoBytes(unsafe.Pointer(out), C.int(outSize)),
+		},
+		Format: MotionAnimatedWebP,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package thumbnailer
+
+import "testing"
+
+func TestProcessMotionThumbValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := [...]struct {
+		name string
+		opts *MotionThumbOptions
+		err  error
+	}{
+		{
+			name: "nil options",
+			opts: nil,
+			err:  nil, // checked separately below; distinct error message
+		},
+		{
+			name: "zero frames",
+			opts: &MotionThumbOptions{Frames: 0, Dims: Dims{150, 150}},
+			err:  ErrInvalidMotionThumb,
+		},
+		{
+			name: "negative frames",
+			opts: &MotionThumbOptions{Frames: -1, Dims: Dims{150, 150}},
+			err:  ErrInvalidMotionThumb,
+		},
+		{
+			name: "zero width",
+			opts: &MotionThumbOptions{Frames: 4, Dims: Dims{0, 150}},
+			err:  ErrInvalidMotionThumb,
+		},
+		{
+			name: "zero height",
+			opts: &MotionThumbOptions{Frames: 4, Dims: Dims{150, 0}},
+			err:  ErrInvalidMotionThumb,
+		},
+	}
+
+	for i := range cases {
+		c := cases[i]
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := openSample(t, "no_sound.mp4")
+			defer f.Close()
+
+			_, err := ProcessMotionThumb(f, Options{MotionThumb: c.opts})
+			if c.opts == nil {
+				if err == nil {
+					t.Fatal("expected error for nil Options.MotionThumb")
+				}
+				return
+			}
+			if err != c.err {
+				t.Fatalf("unexpected error: `%s` : `%s`", c.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package thumbnailer
+
+// #include "src/resize.h"
+import "C"
+
+import (
+	"image"
+	"image/draw"
+	"unsafe"
+)
+
+// cFilter maps a Go Filter to the matching C enum Filter value
+func cFilter(f Filter) C.enum_Filter {
+	switch f {
+	case FilterBicubic:
+		return C.FILTER_BICUBIC
+	case FilterCatmullRom:
+		return C.FILTER_CATMULL_ROM
+	case FilterLanczos3:
+		return C.FILTER_LANCZOS3
+	case FilterNearest:
+		return C.FILTER_NEAREST
+	default: // FilterBilinear
+		return C.FILTER_BILINEAR
+	}
+}
+
+// resizeRGBA resamples img to exactly the given dimensions via the bundled C
+// resampler, using the given Filter, and returns the result as a freshly
+// allocated RGBA image.
+func resizeRGBA(img *image.RGBA, w, h uint, filter Filter) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	if w == 0 || h == 0 {
+		return out
+	}
+
+	srcW, srcH := img.Rect.Dx(), img.Rect.Dy()
+	// The C resampler reads rows packed srcW*4 bytes apart, which a
+	// sub-image, like one returned by cropToAspect, is not
+	if img.Stride != srcW*4 {
+		packed := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+		draw.Draw(packed, packed.Rect, img, img.Rect.Min, draw.Src)
+		img = packed
+	}
+	C.resize_rgba(
+		(*C.uchar)(unsafe.Pointer(&img.Pix[0])), C.uint(srcW), C.uint(srcH),
+		(*C.uchar)(unsafe.Pointer(&out.Pix[0])), C.uint(w), C.uint(h),
+		cFilter(filter),
+	)
+	return out
+}
+
+// toRGBA converts an arbitrary image.Image to the *image.RGBA form the C
+// resampler operates on, copying only when the source is not already RGBA.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out.Set(x-b.Min.X, y-b.Min.Y, img.At(x, y))
+		}
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package thumbnailer
+
+// Source stores the source media file passed for thumbnail extraction and
+// any metadata parsed in the process
+type Source struct {
+	// Data is the complete raw contents of the source file, buffered in
+	// memory
+	Data []byte
+
+	// Mime is the detected MIME type of the source
+	Mime string
+
+	// Extension is the canonical file extension for Mime
+	Extension string
+
+	Dims
+
+	// Length is the play time of audio and video sources
+	Length float64
+
+	// Title and Artist are parsed from embedded media tags, where present
+	Title, Artist string
+}
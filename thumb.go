@@ -0,0 +1,14 @@
+package thumbnailer
+
+// Thumbnail stores a generated thumbnail image and its metadata
+type Thumbnail struct {
+	// IsPNG denotes the thumbnail was encoded as PNG, as the source
+	// contained transparency that a JPEG encoding would have discarded.
+	// Otherwise the thumbnail is encoded as JPEG.
+	IsPNG bool
+
+	Dims
+
+	// Data is the encoded thumbnail image
+	Data []byte
+}
@@ -0,0 +1,504 @@
+// Package thumbnailer provides a unified interface for extracting metadata
+// and generating thumbnails from a wide range of image, video, audio and
+// document formats.
+package thumbnailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+
+	"github.com/cutechan/thumbnailer/cache"
+)
+
+// ProgressCallback is invoked by Process and ProcessRenditions during each
+// expensive processing stage, reporting stage names such as "probe",
+// "decode", "histogram", "cover_art" or "encode". done and total are
+// stage-specific units of work; total is 0 when the stage's extent isn't
+// known in advance.
+type ProgressCallback func(stage string, done, total int64)
+
+// videoMimes is the set of container MIMEs handled via the ffmpeg-backed
+// frame decoder
+var videoMimes = map[string]bool{
+	"video/mp4":        true,
+	"video/webm":       true,
+	"video/x-matroska": true,
+	"video/quicktime":  true,
+	"video/x-msvideo":  true,
+	"video/x-flv":      true,
+	"video/x-ms-wmv":   true,
+}
+
+// audioMimes is the set of container MIMEs handled via the metadata/cover
+// art extractor
+var audioMimes = map[string]bool{
+	"audio/mpeg": true,
+	"audio/ogg":  true,
+	"audio/flac": true,
+}
+
+// documentMimes is the set of MIMEs rendered via Poppler/GraphicsMagick and
+// exempted from Options.MaxSourceDims, as their dimensions describe page
+// geometry rather than pixel buffers
+var documentMimes = map[string]bool{
+	"application/pdf":           true,
+	"image/vnd.adobe.photoshop": true,
+}
+
+// Options suply optional parameters for Process
+type Options struct {
+	// JPEGQuality sets the encoding quality for thumbnails encoded as JPEG
+	JPEGQuality int
+
+	// ThumbDims bounds the default thumbnail returned by Process, using the
+	// Thumbnail (crop-to-fit) mode
+	ThumbDims Dims
+
+	// MaxSourceDims, when non-zero on either axis, rejects sources that
+	// exceed it with ErrTooWide or ErrTooTall. Exempt for document formats,
+	// whose page geometry is independent of render resolution.
+	MaxSourceDims Dims
+
+	// MaxSourcePixels, when non-zero, rejects sources whose Width * Height
+	// exceeds it with ErrTooLarge, even if each individual dimension passes
+	// MaxSourceDims. Exempt for document formats, like MaxSourceDims.
+	MaxSourcePixels uint64
+
+	// Renditions lists the named thumbnail renditions that ProcessRenditions
+	// and ProcessCached produce from the same decoded source. Process
+	// ignores it.
+	Renditions []Rendition
+
+	// Ctx, if set, is checked for cancellation between processing stages.
+	// A video probe/decode/encode run for a large source can take seconds;
+	// Ctx lets a caller give up early instead of blocking for all of them.
+	Ctx context.Context
+
+	// ProgressCallback, if set, is invoked at the start and end of each
+	// processing stage - source probe, video frame decode/seek, histogram
+	// scan for black leader frames, cover-art extraction and final encode.
+	ProgressCallback ProgressCallback
+
+	// MotionThumb, when set, requests an additional animated/sprite preview
+	// strip be produced for video sources via ProcessMotionThumb.
+	MotionThumb *MotionThumbOptions
+
+	// Filter selects the resampling kernel used to downsample source images
+	// to thumbnail dimensions. Defaults to FilterBilinear.
+	Filter Filter
+
+	// Cache, when set, is consulted and populated by ProcessCached, keyed
+	// by source content hash and rendition spec. ProcessRenditions and
+	// Process also populate it as a side effect, if set, even when called
+	// directly instead of through ProcessCached.
+	Cache cache.Cache
+}
+
+// report invokes opts.ProgressCallback, if set
+func (opts Options) report(stage string, done, total int64) {
+	if opts.ProgressCallback != nil {
+		opts.ProgressCallback(stage, done, total)
+	}
+}
+
+// checkCtx returns opts.Ctx's error, if it has already been canceled or has
+// exceeded its deadline
+func (opts Options) checkCtx() error {
+	if opts.Ctx == nil {
+		return nil
+	}
+	select {
+	case <-opts.Ctx.Done():
+		return opts.Ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// decoded holds the result of the one-time, format-specific decode phase
+// shared by all requested renditions
+type decoded struct {
+	source Source
+	image  image.Image
+
+	// hash is the hex-encoded SHA-256 of the raw source bytes, computed
+	// while they are read in, for use as a cache.Key.Hash
+	hash string
+}
+
+// Process reads the source data in rs, be it an image, video or audio
+// file, and returns any extracted metadata in src, as well as a thumbnail
+// conforming to Options.ThumbDims in thumb.
+//
+// Process can still return a valid src with ErrNoCoverArt, if the source
+// has no thumbnailable cover art or video frame, but otherwise parsed fine.
+func Process(rs io.ReadSeeker, opts Options) (src Source, thumb Thumbnail, err error) {
+	dec, err := processSource(rs, opts)
+	src = dec.source
+	if err != nil {
+		return
+	}
+	if dec.image == nil {
+		err = ErrNoCoverArt
+		return
+	}
+
+	opts.report("encode", 0, 1)
+	thumb, err = generateRendition(dec.image, Rendition{
+		Dims: opts.ThumbDims,
+		Mode: ModeThumbnail,
+	}, opts.JPEGQuality, opts.Filter)
+	opts.report("encode", 1, 1)
+	if err == nil {
+		putCache(opts.Cache, dec.hash, Rendition{Dims: opts.ThumbDims, Mode: ModeThumbnail}, thumb)
+	}
+	return
+}
+
+// ProcessRenditions behaves like Process, but decodes the source exactly
+// once and produces every rendition listed in Options.Renditions, returning
+// them keyed by Rendition.Name. Decoding, EXIF-orientation correction, video
+// frame selection and cover-art extraction all happen once; only the final
+// downsample/encode step repeats per rendition.
+func ProcessRenditions(
+	rs io.ReadSeeker, opts Options,
+) (src Source, thumbs map[string]Thumbnail, err error) {
+	dec, err := processSource(rs, opts)
+	src = dec.source
+	if err != nil {
+		return
+	}
+	if dec.image == nil {
+		err = ErrNoCoverArt
+		return
+	}
+
+	thumbs = make(map[string]Thumbnail, len(opts.Renditions))
+	total := int64(len(opts.Renditions))
+	for i, r := range opts.Renditions {
+		if err = opts.checkCtx(); err != nil {
+			return
+		}
+		opts.report("encode", int64(i), total)
+		var t Thumbnail
+		t, err = generateRendition(dec.image, r, opts.JPEGQuality, opts.Filter)
+		if err != nil {
+			return
+		}
+		thumbs[r.Name] = t
+		putCache(opts.Cache, dec.hash, r, t)
+	}
+	opts.report("encode", total, total)
+	return
+}
+
+// ProcessCached behaves like ProcessRenditions, but first checks
+// Options.Cache for every requested rendition, keyed by the source's
+// content hash. If all are already cached, it returns them directly without
+// invoking the format-specific decoder at all. On any cache miss, it falls
+// through to the full ProcessRenditions pipeline, which populates the cache
+// for next time.
+//
+// On a cache hit, each returned Thumbnail.Dims reflects its actual encoded
+// size, not the requested rendition's bounding box - the two differ for
+// ModeFit and for a ModeResize with an auto dimension. src.Title, Artist
+// and Length are recovered cheaply from the container's metadata, same as
+// a full decode would produce. src.Width/Height are only recovered for
+// image sources; for video and audio sources they require the frame
+// decode the cache exists to avoid, so they are left zero on a cache hit.
+func ProcessCached(
+	rs io.ReadSeeker, opts Options,
+) (src Source, thumbs map[string]Thumbnail, err error) {
+	if opts.Cache == nil {
+		return ProcessRenditions(rs, opts)
+	}
+
+	h := sha256.New()
+	buf, err := ioutil.ReadAll(io.TeeReader(rs, h))
+	if err != nil {
+		return
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	thumbs = make(map[string]Thumbnail, len(opts.Renditions))
+	for _, r := range opts.Renditions {
+		data, ok := opts.Cache.Get(cacheKey(hash, r, "jpg"))
+		if !ok {
+			data, ok = opts.Cache.Get(cacheKey(hash, r, "png"))
+		}
+		if !ok {
+			return ProcessRenditions(bytes.NewReader(buf), opts)
+		}
+
+		cfg, _, cfgErr := image.DecodeConfig(bytes.NewReader(data))
+		if cfgErr != nil {
+			return ProcessRenditions(bytes.NewReader(buf), opts)
+		}
+		thumbs[r.Name] = Thumbnail{
+			Dims:  Dims{Width: uint(cfg.Width), Height: uint(cfg.Height)},
+			IsPNG: bytes.HasPrefix(data, []byte("\x89PNG")),
+			Data:  data,
+		}
+	}
+
+	mime, err := detectMIME(buf)
+	if err != nil {
+		return
+	}
+	src = Source{Data: buf, Mime: mime, Extension: extensions[mime]}
+
+	switch {
+	case videoMimes[mime], audioMimes[mime]:
+		if tags, tagErr := readMediaTags(buf); tagErr == nil {
+			src.Title, src.Artist, src.Length = tags.title, tags.artist, tags.length
+		}
+	case !documentMimes[mime]:
+		if cfg, _, cfgErr := image.DecodeConfig(bytes.NewReader(buf)); cfgErr == nil {
+			src.Width, src.Height = uint(cfg.Width), uint(cfg.Height)
+		}
+	}
+	return
+}
+
+// cacheKey builds the cache.Key for rendition r of the source identified by
+// hash, encoded with the given file extension
+func cacheKey(hash string, r Rendition, ext string) cache.Key {
+	return cache.Key{
+		Hash:   hash,
+		Width:  r.Dims.Width,
+		Height: r.Dims.Height,
+		Mode:   r.Mode.String(),
+		Ext:    ext,
+	}
+}
+
+// putCache writes thumb to c under rendition r's key, if c is set. Cache
+// write failures are not fatal to Process/ProcessRenditions: the rendition
+// was still produced successfully, just not persisted for reuse.
+func putCache(c cache.Cache, hash string, r Rendition, thumb Thumbnail) {
+	if c == nil {
+		return
+	}
+	ext := "jpg"
+	if thumb.IsPNG {
+		ext = "png"
+	}
+	c.Put(cacheKey(hash, r, ext), thumb.Data)
+}
+
+// processSource buffers rs, detects its MIME type, validates its
+// dimensions and dispatches to the appropriate format-specific decoder.
+// This is the single decode pass shared by Process and ProcessRenditions.
+func processSource(rs io.ReadSeeker, opts Options) (decoded, error) {
+	opts.report("probe", 0, 1)
+	h := sha256.New()
+	buf, err := ioutil.ReadAll(io.TeeReader(rs, h))
+	if err != nil {
+		return decoded{}, err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	mime, err := detectMIME(buf)
+	if err != nil {
+		return decoded{}, err
+	}
+	opts.report("probe", 1, 1)
+
+	src := Source{
+		Data:      buf,
+		Mime:      mime,
+		Extension: extensions[mime],
+	}
+
+	if err := opts.checkCtx(); err != nil {
+		return decoded{source: src, hash: hash}, err
+	}
+
+	var img image.Image
+	switch {
+	case documentMimes[mime]:
+		img, err = decodeDocument(buf, mime)
+	case videoMimes[mime]:
+		var dims Dims
+		if dims, err = probeVideoDims(buf); err == nil {
+			err = validateDims(dims, opts.MaxSourceDims, opts.MaxSourcePixels)
+		}
+		if err != nil {
+			return decoded{source: src, hash: hash}, err
+		}
+		if tags, tagErr := readMediaTags(buf); tagErr == nil {
+			src.Title, src.Artist, src.Length = tags.title, tags.artist, tags.length
+		}
+		opts.report("histogram", 0, 1)
+		opts.report("decode", 0, 1)
+		img, err = decodeVideoFrame(buf)
+		opts.report("histogram", 1, 1)
+		opts.report("decode", 1, 1)
+	case audioMimes[mime]:
+		opts.report("cover_art", 0, 1)
+		var tags Source
+		var cover []byte
+		tags, cover, err = decodeAudio(buf, mime)
+		src.Title, src.Artist, src.Length = tags.Title, tags.Artist, tags.Length
+		if err == nil && len(cover) != 0 {
+			img, err = decodeImageBytes(cover)
+		}
+		opts.report("cover_art", 1, 1)
+	default:
+		var cfg image.Config
+		if cfg, _, err = image.DecodeConfig(bytes.NewReader(buf)); err == nil {
+			dims := Dims{Width: uint(cfg.Width), Height: uint(cfg.Height)}
+			err = validateDims(dims, opts.MaxSourceDims, opts.MaxSourcePixels)
+		}
+		if err != nil {
+			return decoded{source: src, hash: hash}, err
+		}
+		img, err = decodeImage(buf, mime)
+	}
+	if err == ErrNoCoverArt {
+		return decoded{source: src, hash: hash}, ErrNoCoverArt
+	}
+	if err != nil {
+		return decoded{source: src, hash: hash}, err
+	}
+
+	b := img.Bounds()
+	src.Width, src.Height = uint(b.Dx()), uint(b.Dy())
+	// Video and image sources were already validated above, before the
+	// expensive decode ran. This second check is what actually protects
+	// the audioMimes cover-art path, whose dims aren't known until
+	// decodeImageBytes has already decoded it.
+	if !documentMimes[mime] {
+		if err := validateDims(src.Dims, opts.MaxSourceDims, opts.MaxSourcePixels); err != nil {
+			return decoded{source: src, hash: hash}, err
+		}
+	}
+
+	return decoded{source: src, image: img, hash: hash}, nil
+}
+
+// decodeImageBytes sniffs and decodes an arbitrary image buffer - used for
+// embedded cover art, which arrives as raw JPEG/PNG bytes rather than a
+// pre-identified MIME type.
+func decodeImageBytes(buf []byte) (image.Image, error) {
+	mime, err := detectMIME(buf)
+	if err != nil {
+		return nil, err
+	}
+	return decodeImage(buf, mime)
+}
+
+// validateDims checks src against max, if max has either dimension set, and
+// against maxPixels, if it is non-zero
+func validateDims(src, max Dims, maxPixels uint64) error {
+	if max.Width != 0 && src.Width > max.Width {
+		return ErrTooWide
+	}
+	if max.Height != 0 && src.Height > max.Height {
+		return ErrTooTall
+	}
+	if maxPixels != 0 && uint64(src.Width)*uint64(src.Height) > maxPixels {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// generateRendition produces a single Thumbnail from a decoded source image
+// according to r.Dims and r.Mode, resampling with filter
+func generateRendition(src image.Image, r Rendition, jpegQuality int, filter Filter) (Thumbnail, error) {
+	rgba := toRGBA(src)
+	w, h := fitDims(uint(rgba.Rect.Dx()), uint(rgba.Rect.Dy()), r.Dims, r.Mode)
+
+	var out *image.RGBA
+	switch r.Mode {
+	case ModeFit, ModeResize:
+		out = resizeRGBA(rgba, w, h, filter)
+	default: // ModeThumbnail: crop-to-fit
+		if uint(rgba.Rect.Dx()) <= r.Dims.Width && uint(rgba.Rect.Dy()) <= r.Dims.Height {
+			out = rgba // already fits inside the box: kept at source size
+		} else {
+			out = resizeRGBA(cropToAspect(rgba, r.Dims), r.Dims.Width, r.Dims.Height, filter)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	isPNG := hasAlpha(out)
+	var err error
+	if isPNG {
+		err = png.Encode(buf, out)
+	} else {
+		err = jpeg.Encode(buf, out, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return Thumbnail{}, err
+	}
+
+	return Thumbnail{
+		IsPNG: isPNG,
+		Dims:  Dims{Width: uint(out.Rect.Dx()), Height: uint(out.Rect.Dy())},
+		Data:  buf.Bytes(),
+	}, nil
+}
+
+// fitDims computes the output dimensions for mode m fitting a srcW x srcH
+// image into the box described by d. For Resize, a zero dimension in d is
+// computed from the source aspect ratio.
+func fitDims(srcW, srcH uint, d Dims, m Mode) (w, h uint) {
+	switch m {
+	case ModeFit:
+		if srcW == 0 || srcH == 0 || d.Width == 0 || d.Height == 0 {
+			return d.Width, d.Height
+		}
+		wRatio := float64(d.Width) / float64(srcW)
+		hRatio := float64(d.Height) / float64(srcH)
+		ratio := wRatio
+		if hRatio < ratio {
+			ratio = hRatio
+		}
+		return uint(float64(srcW) * ratio), uint(float64(srcH) * ratio)
+	case ModeResize:
+		switch {
+		case d.Width == 0 && d.Height != 0:
+			return uint(float64(srcW) * float64(d.Height) / float64(srcH)), d.Height
+		case d.Height == 0 && d.Width != 0:
+			return d.Width, uint(float64(srcH) * float64(d.Width) / float64(srcW))
+		default:
+			return d.Width, d.Height
+		}
+	default: // ModeThumbnail
+		return d.Width, d.Height
+	}
+}
+
+// cropToAspect crops src to the aspect ratio of d, centering the crop,
+// ready to be resized to exactly d by the caller
+func cropToAspect(src *image.RGBA, d Dims) *image.RGBA {
+	srcW, srcH := uint(src.Rect.Dx()), uint(src.Rect.Dy())
+	if srcW == 0 || srcH == 0 || d.Width == 0 || d.Height == 0 {
+		return src
+	}
+
+	srcRatio := float64(srcW) / float64(srcH)
+	dstRatio := float64(d.Width) / float64(d.Height)
+
+	cropW, cropH := srcW, srcH
+	if srcRatio > dstRatio {
+		cropW = uint(float64(srcH) * dstRatio)
+	} else if srcRatio < dstRatio {
+		cropH = uint(float64(srcW) / dstRatio)
+	}
+	if cropW == srcW && cropH == srcH {
+		return src
+	}
+
+	x0 := src.Rect.Min.X + int(srcW-cropW)/2
+	y0 := src.Rect.Min.Y + int(srcH-cropH)/2
+	return src.SubImage(image.Rect(x0, y0, x0+int(cropW), y0+int(cropH))).(*image.RGBA)
+}